@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// renderGraph turns the image set into one of dockviz's export formats.
+// "dot" is the original Graphviz text dockviz has always printed. "svg" and
+// "png" pipe that text through Graphviz's `dot` binary for the user;
+// "mermaid" and "json-graph" need no Graphviz at all.
+func renderGraph(images *[]Image, format string, withLayers bool, withPlatforms bool, withContainers bool) (string, error) {
+	dot := jsonToDotWithOptions(images, withLayers, withPlatforms, withContainers)
+
+	switch format {
+	case "", "dot":
+		return dot, nil
+	case "svg":
+		return renderWithGraphviz(dot, "svg")
+	case "png":
+		return renderWithGraphviz(dot, "png")
+	case "mermaid":
+		return dotToMermaid(images, withLayers, withPlatforms, withContainers), nil
+	case "json-graph":
+		return dotToJSONGraph(images, withLayers, withPlatforms, withContainers)
+	default:
+		return "", fmt.Errorf("unsupported --format %q, expected dot, svg, png, mermaid, or json-graph", format)
+	}
+}
+
+// renderWithGraphviz shells out to the `dot` binary to rasterize svg/png.
+//
+// This is a deliberate scope reduction, not an oversight: dockviz does not
+// bundle its own DOT layout engine (e.g. a gonum-based in-process
+// rasterizer), so svg/png depend on Graphviz being installed, the same way
+// --engine dropped buildkit/containerd support rather than half-implement
+// it. This mirrors the long-standing "pipe --dot output through dot" workflow,
+// just done for the user. If --format=svg/png needs to work without
+// Graphviz on PATH, that bundled renderer is the follow-up.
+func renderWithGraphviz(dot string, outputType string) (string, error) {
+	dotPath, err := exec.LookPath("dot")
+	if err != nil {
+		return "", fmt.Errorf("--format=%s requires Graphviz's `dot` binary on PATH: %s", outputType, err)
+	}
+
+	cmd := exec.Command(dotPath, "-T"+outputType)
+	cmd.Stdin = strings.NewReader(dot)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("dot -T%s failed: %s: %s", outputType, err, stderr.String())
+	}
+
+	return out.String(), nil
+}
+
+// dotToMermaid emits a `graph TD` block suitable for pasting straight into
+// a markdown file's ```mermaid fence.
+func dotToMermaid(images *[]Image, withLayers bool, withPlatforms bool, withContainers bool) string {
+	var buffer bytes.Buffer
+	buffer.WriteString("graph TD\n")
+
+	roots := buildImageNodes(*images, "", withLayers, withPlatforms, withContainers)
+
+	var visit func(node Node)
+	visit = func(node Node) {
+		label := strings.ReplaceAll(node.Label(false), "\"", "'")
+		fmt.Fprintf(&buffer, "  %s[\"%s\"]\n", mermaidId(node.NodeId()), label)
+		for _, child := range node.Children() {
+			fmt.Fprintf(&buffer, "  %s --> %s\n", mermaidId(node.NodeId()), mermaidId(child.NodeId()))
+			visit(child)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+
+	return buffer.String()
+}
+
+// mermaidId sanitizes a node id into something mermaid will accept as a
+// bare node identifier (no colons, slashes, or whitespace).
+func mermaidId(id string) string {
+	replacer := strings.NewReplacer(":", "_", "/", "_", " ", "_", "@", "_")
+	return "n" + replacer.Replace(id)
+}
+
+// cytoscapeGraph is the {nodes, edges} shape cytoscape.js expects to be
+// handed directly to `cy.add(...)`.
+type cytoscapeGraph struct {
+	Nodes []cytoscapeNode `json:"nodes"`
+	Edges []cytoscapeEdge `json:"edges"`
+}
+
+type cytoscapeNode struct {
+	Data cytoscapeNodeData `json:"data"`
+}
+
+type cytoscapeNodeData struct {
+	Id    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+}
+
+type cytoscapeEdge struct {
+	Data cytoscapeEdgeData `json:"data"`
+}
+
+type cytoscapeEdgeData struct {
+	Id     string `json:"id"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+func dotToJSONGraph(images *[]Image, withLayers bool, withPlatforms bool, withContainers bool) (string, error) {
+	graph := cytoscapeGraph{}
+
+	roots := buildImageNodes(*images, "", withLayers, withPlatforms, withContainers)
+
+	var visit func(node Node)
+	visit = func(node Node) {
+		graph.Nodes = append(graph.Nodes, cytoscapeNode{Data: cytoscapeNodeData{
+			Id:    node.NodeId(),
+			Label: node.Label(false),
+			Kind:  node.Kind(),
+		}})
+		for _, child := range node.Children() {
+			graph.Edges = append(graph.Edges, cytoscapeEdge{Data: cytoscapeEdgeData{
+				Id:     node.NodeId() + "->" + child.NodeId(),
+				Source: node.NodeId(),
+				Target: child.NodeId(),
+			}})
+			visit(child)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+
+	out, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding json-graph: %s", err)
+	}
+
+	return string(out), nil
+}
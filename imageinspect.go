@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// enrichImageDetails fills in the Layers, Platforms, and/or Containers
+// fields that --layers, --platforms, and --with-containers need. Each is a
+// second round trip to the daemon, so none of it runs unless the
+// corresponding flag is set. The round trips themselves only know how to
+// talk to Docker (ImageHistory, InspectDistribution, ListContainers), so
+// they're rejected outright for any other engine rather than silently
+// dialing Docker with image IDs that came from somewhere else.
+func enrichImageDetails(images []Image, engine string, withLayers bool, withPlatforms bool, withContainers bool) ([]Image, error) {
+	if !withLayers && !withPlatforms && !withContainers {
+		return images, nil
+	}
+
+	if engine != "docker" {
+		return nil, fmt.Errorf("--layers, --platforms, and --with-containers require --engine=docker, not %q", engine)
+	}
+
+	client, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range images {
+		if withLayers {
+			layers, err := fetchLayers(client, images[i].Id)
+			if err != nil {
+				return nil, fmt.Errorf("fetching history for %s: %s", truncate(images[i].Id), err)
+			}
+			images[i].Layers = layers
+		}
+
+		if withPlatforms {
+			platforms, digest, err := fetchPlatforms(client, images[i])
+			if err != nil {
+				return nil, fmt.Errorf("fetching manifest info for %s: %s", truncate(images[i].Id), err)
+			}
+			images[i].Platforms = platforms
+			images[i].Digest = digest
+		}
+	}
+
+	if withContainers {
+		if err := attachContainers(client, images); err != nil {
+			return nil, fmt.Errorf("listing containers: %s", err)
+		}
+	}
+
+	return images, nil
+}
+
+// attachContainers fills in each image's Containers field by matching
+// container.Image (an id or repo:tag, depending on Docker version) against
+// the image's own id and tags.
+func attachContainers(client *docker.Client, images []Image) error {
+	containers, err := client.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[string]int, len(images))
+	byTag := make(map[string]int, len(images))
+	for i, image := range images {
+		byId[image.Id] = i
+		byId[truncate(image.Id)] = i
+		for _, repotag := range image.RepoTags {
+			byTag[repotag] = i
+		}
+	}
+
+	for _, container := range containers {
+		idx, exists := byId[container.Image]
+		if !exists {
+			idx, exists = byTag[container.Image]
+		}
+		if !exists {
+			continue
+		}
+
+		name := container.ID
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		images[idx].Containers = append(images[idx].Containers, ContainerRef{
+			Id:     container.ID,
+			Name:   name,
+			Status: container.Status,
+		})
+	}
+
+	return nil
+}
+
+func fetchLayers(client *docker.Client, id string) ([]ImageLayer, error) {
+	history, err := client.ImageHistory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]ImageLayer, 0, len(history))
+	for _, entry := range history {
+		layers = append(layers, ImageLayer{
+			CreatedBy: entry.CreatedBy,
+			Size:      entry.Size,
+		})
+	}
+
+	return layers, nil
+}
+
+// fetchPlatforms reports the platforms a manifest list covers by calling the
+// daemon's distribution-inspect endpoint (GET /distribution/{name}/json),
+// which asks the registry for the manifest list behind a repo:tag rather
+// than just describing the single platform already pulled locally.
+//
+// That endpoint needs a named reference, so untagged/dangling images (no
+// RepoTags, i.e. "<none>:<none>") fall back to the one platform the local
+// image itself reports - there's no registry to ask about an image with no
+// name.
+func fetchPlatforms(client *docker.Client, image Image) ([]string, string, error) {
+	repoTag := ""
+	for _, tag := range image.RepoTags {
+		if tag != "<none>:<none>" {
+			repoTag = tag
+			break
+		}
+	}
+
+	if repoTag == "" {
+		return fetchLocalPlatform(client, image.Id)
+	}
+
+	dist, err := client.InspectDistribution(repoTag)
+	if err != nil {
+		// the registry may be unreachable, unauthenticated, or simply not
+		// store a manifest list for this tag - fall back rather than fail
+		// the whole --platforms run over one image.
+		return fetchLocalPlatform(client, image.Id)
+	}
+
+	if len(dist.Platforms) == 0 {
+		return fetchLocalPlatform(client, image.Id)
+	}
+
+	platforms := make([]string, 0, len(dist.Platforms))
+	for _, p := range dist.Platforms {
+		platform := p.OS + "/" + p.Architecture
+		if p.Variant != "" {
+			platform += "/" + p.Variant
+		}
+		platforms = append(platforms, platform)
+	}
+
+	return platforms, string(dist.Descriptor.Digest), nil
+}
+
+// fetchLocalPlatform describes the single platform of the image already
+// pulled onto this host.
+func fetchLocalPlatform(client *docker.Client, id string) ([]string, string, error) {
+	image, err := client.InspectImage(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	platform := image.OS + "/" + image.Architecture
+
+	return []string{platform}, image.ID, nil
+}
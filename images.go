@@ -1,8 +1,6 @@
 package main
 
 import (
-	"github.com/fsouza/go-dockerclient"
-
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -13,18 +11,45 @@ import (
 
 type Image struct {
 	Id          string
-	ParentId    string   `json:",omitempty"`
-	RepoTags    []string `json:",omitempty"`
+	ParentId    string            `json:",omitempty"`
+	RepoTags    []string          `json:",omitempty"`
+	Labels      map[string]string `json:",omitempty"`
 	VirtualSize int64
 	Size        int64
 	Created     int64
+	Digest      string         `json:",omitempty"`
+	Platforms   []string       `json:",omitempty"` // e.g. "linux/amd64", "linux/arm64/v8", fan out from a manifest list
+	Layers      []ImageLayer   `json:",omitempty"` // build history, most recent first
+	Containers  []ContainerRef `json:",omitempty"` // containers currently pinning this image
+}
+
+// ImageLayer is one entry of an image's build history, as returned by
+// `docker history` / `/images/{id}/history`.
+type ImageLayer struct {
+	CreatedBy string
+	Size      int64
+}
+
+// ContainerRef is the minimal information --with-containers needs about a
+// container pinned to an image.
+type ContainerRef struct {
+	Id     string
+	Name   string
+	Status string
 }
 
 type ImagesCommand struct {
-	Dot        bool `short:"d" long:"dot" description:"Show image information as Graphviz dot."`
-	Tree       bool `short:"t" long:"tree" description:"Show image information as tree."`
-	Short      bool `short:"s" long:"short" description:"Show short summary of images (repo name and list of tags)."`
-	NoTruncate bool `short:"n" long:"no-trunc" description:"Don't truncate the image IDs."`
+	Dot            bool     `short:"d" long:"dot" description:"Show image information as Graphviz dot."`
+	Tree           bool     `short:"t" long:"tree" description:"Show image information as tree."`
+	Short          bool     `short:"s" long:"short" description:"Show short summary of images (repo name and list of tags)."`
+	NoTruncate     bool     `short:"n" long:"no-trunc" description:"Don't truncate the image IDs."`
+	Filter         []string `short:"f" long:"filter" description:"Filter output based on conditions provided (e.g. 'label=<key>=<value>', 'dangling=true', 'since=<image>', 'before=<image>')."`
+	Engine         string   `long:"engine" description:"Image source to query: docker, podman, or auto." default:"auto"`
+	Layers         bool     `long:"layers" description:"Show each image's build history as child nodes."`
+	Platforms      bool     `long:"platforms" description:"Fan out manifest-list images into one node per platform."`
+	Tui            bool     `long:"tui" description:"Launch an interactive, navigable tree browser instead of printing output. Inspect/diff/rmi (i/d/x) only work against the docker engine; they're disabled when --engine=podman."`
+	Format         string   `long:"format" description:"Output format for --dot: dot (default), svg, png, mermaid, or json-graph. svg/png shell out to Graphviz's dot binary, so they require it on PATH." default:"dot"`
+	WithContainers bool     `long:"with-containers" description:"Attach containers as leaf nodes under the image they were started from."`
 }
 
 var imagesCommand ImagesCommand
@@ -32,6 +57,10 @@ var imagesCommand ImagesCommand
 func (x *ImagesCommand) Execute(args []string) error {
 
 	var images *[]Image
+	// --tui's inspect/diff/rmi actions need a live connection of their own;
+	// they only know how to speak Docker, so default to that engine and
+	// only widen it once we know we actually resolved a podman source.
+	engine := "docker"
 
 	stat, err := os.Stdin.Stat()
 	if err != nil {
@@ -52,38 +81,46 @@ func (x *ImagesCommand) Execute(args []string) error {
 
 	} else {
 
-		client, err := connect()
+		source, err := resolveImageSource(imagesCommand.Engine)
 		if err != nil {
 			return err
 		}
+		engine = source.Engine()
 
-		clientImages, err := client.ListImages(docker.ListImagesOptions{All: true})
+		ims, err := source.ListImages()
 		if err != nil {
-			if in_docker := os.Getenv("IN_DOCKER"); len(in_docker) > 0 {
-				return fmt.Errorf("Unable to access Docker socket, please run like this:\n  docker run --rm -v /var/run/docker.sock:/var/run/docker.sock nate/dockviz images <args>\nFor more help, run 'dockviz help'")
-			} else {
-				return fmt.Errorf("Unable to connect: %s\nFor help, run 'dockviz help'", err)
-			}
+			return err
 		}
 
-		var ims []Image
-		for _, image := range clientImages {
-			// fmt.Println(image)
-			ims = append(ims, Image{
-				image.ID,
-				image.ParentID,
-				image.RepoTags,
-				image.VirtualSize,
-				image.Size,
-				image.Created,
-			})
+		ims, err = enrichImageDetails(ims, engine, imagesCommand.Layers, imagesCommand.Platforms, imagesCommand.WithContainers)
+		if err != nil {
+			return err
 		}
 
 		images = &ims
 	}
 
-	if imagesCommand.Dot {
-		fmt.Printf(jsonToDot(images))
+	var nameFilter = ""
+	if len(args) > 0 && !imagesCommand.Tree {
+		// --tree's positional arg already means something else (the start
+		// image to descend from, handled below), not a prune-to-match
+		// filter, so don't let filterImages strip its descendants away.
+		nameFilter = args[0]
+	}
+
+	images, err = filterImages(images, imagesCommand.Filter, nameFilter)
+	if err != nil {
+		return err
+	}
+
+	if imagesCommand.Tui {
+		return runImagesTUI(*images, engine, imagesCommand.NoTruncate, imagesCommand.Layers, imagesCommand.Platforms, imagesCommand.WithContainers)
+	} else if imagesCommand.Dot {
+		out, err := renderGraph(images, imagesCommand.Format, imagesCommand.Layers, imagesCommand.Platforms, imagesCommand.WithContainers)
+		if err != nil {
+			return err
+		}
+		fmt.Printf(out)
 	} else if imagesCommand.Tree {
 
 		var startImage = ""
@@ -125,98 +162,40 @@ func (x *ImagesCommand) Execute(args []string) error {
 			}
 		}
 
-		fmt.Printf(jsonToTree(images, startImage, imagesCommand.NoTruncate))
+		fmt.Printf(jsonToTree(images, startImage, imagesCommand.NoTruncate, imagesCommand.Layers, imagesCommand.Platforms, imagesCommand.WithContainers))
 	} else if imagesCommand.Short {
 		fmt.Printf(jsonToShort(images))
 	} else {
-		return fmt.Errorf("Please specify either --dot, --tree, or --short")
+		return fmt.Errorf("Please specify either --dot, --tree, --short, or --tui")
 	}
 
 	return nil
 }
 
-func jsonToTree(images *[]Image, startImageArg string, noTrunc bool) string {
+func jsonToTree(images *[]Image, startImageArg string, noTrunc bool, withLayers bool, withPlatforms bool, withContainers bool) string {
 	var buffer bytes.Buffer
 
-	var startImage Image
-
-	var roots []Image
-	var byParent = make(map[string][]Image)
-	for _, image := range *images {
-		if image.ParentId == "" {
-			roots = append(roots, image)
-		} else {
-			if children, exists := byParent[image.ParentId]; exists {
-				byParent[image.ParentId] = append(children, image)
-			} else {
-				byParent[image.ParentId] = []Image{image}
-			}
-		}
-
-		if startImageArg != "" {
+	var resolvedStart string
+	if startImageArg != "" {
+		for _, image := range *images {
 			if startImageArg == image.Id || startImageArg == truncate(image.Id) {
-				startImage = image
+				resolvedStart = image.Id
 			}
 
 			for _, repotag := range image.RepoTags {
 				if repotag == startImageArg {
-					startImage = image
+					resolvedStart = image.Id
 				}
 			}
 		}
 	}
 
-	if startImageArg != "" {
-		WalkTree(&buffer, noTrunc, []Image{startImage}, byParent, "")
-	} else {
-		WalkTree(&buffer, noTrunc, roots, byParent, "")
-	}
+	nodes := buildImageNodes(*images, resolvedStart, withLayers, withPlatforms, withContainers)
+	WalkTree(&buffer, noTrunc, nodes, "")
 
 	return buffer.String()
 }
 
-func WalkTree(buffer *bytes.Buffer, noTrunc bool, images []Image, byParent map[string][]Image, prefix string) {
-	if len(images) > 1 {
-		length := len(images)
-		for index, image := range images {
-			if index+1 == length {
-				PrintTreeNode(buffer, noTrunc, image, prefix+"└─")
-				if subimages, exists := byParent[image.Id]; exists {
-					WalkTree(buffer, noTrunc, subimages, byParent, prefix+"  ")
-				}
-			} else {
-				PrintTreeNode(buffer, noTrunc, image, prefix+"├─")
-				if subimages, exists := byParent[image.Id]; exists {
-					WalkTree(buffer, noTrunc, subimages, byParent, prefix+"│ ")
-				}
-			}
-		}
-	} else {
-		for _, image := range images {
-			PrintTreeNode(buffer, noTrunc, image, prefix+"└─")
-			if subimages, exists := byParent[image.Id]; exists {
-				WalkTree(buffer, noTrunc, subimages, byParent, prefix+"  ")
-			}
-		}
-	}
-}
-
-func PrintTreeNode(buffer *bytes.Buffer, noTrunc bool, image Image, prefix string) {
-	var imageID string
-	if noTrunc {
-		imageID = image.Id
-	} else {
-		imageID = truncate(image.Id)
-	}
-
-	buffer.WriteString(fmt.Sprintf("%s%s Virtual Size: %s", prefix, imageID, humanSize(image.VirtualSize)))
-	if image.RepoTags[0] != "<none>:<none>" {
-		buffer.WriteString(fmt.Sprintf(" Tags: %s\n", strings.Join(image.RepoTags, ", ")))
-	} else {
-		buffer.WriteString(fmt.Sprintf("\n"))
-	}
-}
-
 func humanSize(raw int64) string {
 	sizes := []string{"B", "KB", "MB", "GB", "TB"}
 
@@ -251,20 +230,139 @@ func parseImagesJSON(rawJSON []byte) (*[]Image, error) {
 	return &images, nil
 }
 
-func jsonToDot(images *[]Image) string {
+// filterImages narrows images down to those matching every --filter
+// predicate and, if nameFilter is non-empty, to images whose id or repo:tag
+// contains it. Ancestors of any surviving image are kept as well so the
+// resulting set still forms a connected tree/graph.
+func filterImages(images *[]Image, filters []string, nameFilter string) (*[]Image, error) {
+	if len(filters) == 0 && nameFilter == "" {
+		return images, nil
+	}
 
-	var buffer bytes.Buffer
-	buffer.WriteString("digraph docker {\n")
+	byId := make(map[string]Image)
+	for _, image := range *images {
+		byId[image.Id] = image
+	}
+
+	matches := func(image Image) (bool, error) {
+		for _, filter := range filters {
+			parts := strings.SplitN(filter, "=", 2)
+			if len(parts) != 2 {
+				return false, fmt.Errorf("invalid filter %q, expected key=value", filter)
+			}
+
+			key, value := parts[0], parts[1]
+			switch key {
+			case "label":
+				labelParts := strings.SplitN(value, "=", 2)
+				if len(labelParts) == 2 {
+					if image.Labels[labelParts[0]] != labelParts[1] {
+						return false, nil
+					}
+				} else if _, exists := image.Labels[value]; !exists {
+					return false, nil
+				}
+			case "dangling":
+				isDangling := len(image.RepoTags) == 0 || image.RepoTags[0] == "<none>:<none>"
+				if value == "true" && !isDangling {
+					return false, nil
+				}
+				if value == "false" && isDangling {
+					return false, nil
+				}
+			case "since":
+				since, exists := byId[resolveImageRef(byId, value)]
+				if !exists || image.Created <= since.Created {
+					return false, nil
+				}
+			case "before":
+				before, exists := byId[resolveImageRef(byId, value)]
+				if !exists || image.Created >= before.Created {
+					return false, nil
+				}
+			default:
+				return false, fmt.Errorf("unsupported filter key %q", key)
+			}
+		}
+
+		if nameFilter != "" {
+			if !strings.Contains(image.Id, nameFilter) {
+				found := false
+				for _, repotag := range image.RepoTags {
+					if strings.Contains(repotag, nameFilter) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					return false, nil
+				}
+			}
+		}
+
+		return true, nil
+	}
 
+	kept := make(map[string]bool)
 	for _, image := range *images {
-		if image.ParentId == "" {
-			buffer.WriteString(fmt.Sprintf(" base -> \"%s\" [style=invis]\n", truncate(image.Id)))
-		} else {
-			buffer.WriteString(fmt.Sprintf(" \"%s\" -> \"%s\"\n", truncate(image.ParentId), truncate(image.Id)))
+		ok, err := matches(image)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		// walk up the parent chain so ancestors of a match are preserved
+		for id := image.Id; id != ""; {
+			if kept[id] {
+				break
+			}
+			kept[id] = true
+			id = byId[id].ParentId
+		}
+	}
+
+	var filtered []Image
+	for _, image := range *images {
+		if kept[image.Id] {
+			filtered = append(filtered, image)
 		}
-		if image.RepoTags[0] != "<none>:<none>" {
-			buffer.WriteString(fmt.Sprintf(" \"%s\" [label=\"%s\\n%s\",shape=box,fillcolor=\"paleturquoise\",style=\"filled,rounded\"];\n", truncate(image.Id), truncate(image.Id), strings.Join(image.RepoTags, "\\n")))
+	}
+
+	return &filtered, nil
+}
+
+// resolveImageRef maps an id/repo:tag reference passed to --filter=since=...
+// or --filter=before=... back to the image id it identifies.
+func resolveImageRef(byId map[string]Image, ref string) string {
+	if _, exists := byId[ref]; exists {
+		return ref
+	}
+
+	for id, image := range byId {
+		if strings.Index(id, ref) == 0 {
+			return id
 		}
+		for _, repotag := range image.RepoTags {
+			if repotag == ref {
+				return id
+			}
+		}
+	}
+
+	return ref
+}
+
+func jsonToDotWithOptions(images *[]Image, withLayers bool, withPlatforms bool, withContainers bool) string {
+	var buffer bytes.Buffer
+	buffer.WriteString("digraph docker {\n")
+
+	roots := buildImageNodes(*images, "", withLayers, withPlatforms, withContainers)
+	for _, root := range roots {
+		buffer.WriteString(fmt.Sprintf(" base -> \"%s\" [style=invis]\n", root.NodeId()))
+		writeDotNode(&buffer, root)
+		writeDotChildren(&buffer, root)
 	}
 
 	buffer.WriteString(" base [style=invisible]\n}\n")
@@ -272,6 +370,51 @@ func jsonToDot(images *[]Image) string {
 	return buffer.String()
 }
 
+func writeDotChildren(buffer *bytes.Buffer, node Node) {
+	for _, child := range node.Children() {
+		buffer.WriteString(fmt.Sprintf(" \"%s\" -> \"%s\"\n", node.NodeId(), child.NodeId()))
+		writeDotNode(buffer, child)
+		writeDotChildren(buffer, child)
+	}
+}
+
+// writeDotNode styles a node box by kind: images keep the original
+// paleturquoise look, layers render as plain grey notes, platform fan-out
+// nodes get a distinct lavender shade so a manifest list's architectures are
+// easy to pick out at a glance, and containers get the ellipse shape the
+// containers subcommand already uses for running containers.
+func writeDotNode(buffer *bytes.Buffer, node Node) {
+	switch n := node.(type) {
+	case *ImageNode:
+		if len(n.Image.RepoTags) > 0 && n.Image.RepoTags[0] != "<none>:<none>" {
+			// escape each tag on its own so the \n between them stays a DOT line break
+			tags := make([]string, len(n.Image.RepoTags))
+			for i, repotag := range n.Image.RepoTags {
+				tags[i] = dotEscape(repotag)
+			}
+			buffer.WriteString(fmt.Sprintf(" \"%s\" [label=\"%s\\n%s\",shape=box,fillcolor=\"paleturquoise\",style=\"filled,rounded\"];\n",
+				n.NodeId(), dotEscape(truncate(n.Image.Id)), strings.Join(tags, "\\n")))
+		}
+	case *LayerNode:
+		buffer.WriteString(fmt.Sprintf(" \"%s\" [label=\"%s\",shape=note,fillcolor=\"gainsboro\",style=\"filled\"];\n",
+			n.NodeId(), dotEscape(n.Label(false))))
+	case *PlatformNode:
+		buffer.WriteString(fmt.Sprintf(" \"%s\" [label=\"%s\",shape=box,fillcolor=\"lavender\",style=\"filled,rounded\"];\n",
+			n.NodeId(), dotEscape(n.Label(false))))
+	case *ContainerNode:
+		buffer.WriteString(fmt.Sprintf(" \"%s\" [label=\"%s\\n%s\",shape=ellipse,fillcolor=\"lightyellow\",style=\"filled\"];\n",
+			n.NodeId(), dotEscape(n.Container.Name), dotEscape(n.Container.Status)))
+	}
+}
+
+// dotEscape escapes backslashes and double quotes so arbitrary text (a
+// layer's CreatedBy history entry, a container name, ...) is safe to drop
+// into a DOT quoted string such as label="...". Without it, content like
+// Docker's own `CMD ["/bin/sh"]` history entries breaks the generated DOT.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
 func jsonToShort(images *[]Image) string {
 	var buffer bytes.Buffer
 
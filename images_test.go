@@ -0,0 +1,114 @@
+package main
+
+import "testing"
+
+func idsOf(images *[]Image) []string {
+	var ids []string
+	for _, image := range *images {
+		ids = append(ids, image.Id)
+	}
+	return ids
+}
+
+func containsId(ids []string, id string) bool {
+	for _, got := range ids {
+		if got == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFilterImagesNoFilters(t *testing.T) {
+	images := &[]Image{{Id: "a"}, {Id: "b"}}
+
+	filtered, err := filterImages(images, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(*filtered) != 2 {
+		t.Fatalf("expected both images kept, got %v", idsOf(filtered))
+	}
+}
+
+func TestFilterImagesDangling(t *testing.T) {
+	images := &[]Image{
+		{Id: "tagged", RepoTags: []string{"repo:latest"}},
+		{Id: "dangling", RepoTags: []string{"<none>:<none>"}},
+	}
+
+	filtered, err := filterImages(images, []string{"dangling=true"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ids := idsOf(filtered); len(ids) != 1 || ids[0] != "dangling" {
+		t.Fatalf("expected only the dangling image, got %v", ids)
+	}
+}
+
+func TestFilterImagesLabel(t *testing.T) {
+	images := &[]Image{
+		{Id: "a", Labels: map[string]string{"env": "prod"}},
+		{Id: "b", Labels: map[string]string{"env": "dev"}},
+	}
+
+	filtered, err := filterImages(images, []string{"label=env=prod"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ids := idsOf(filtered); len(ids) != 1 || ids[0] != "a" {
+		t.Fatalf("expected only image a, got %v", ids)
+	}
+}
+
+func TestFilterImagesKeepsMatchedAncestors(t *testing.T) {
+	images := &[]Image{
+		{Id: "grandparent", Created: 1},
+		{Id: "parent", ParentId: "grandparent", Created: 2},
+		{Id: "child", ParentId: "parent", RepoTags: []string{"repo:latest"}, Created: 3},
+	}
+
+	filtered, err := filterImages(images, nil, "repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ids := idsOf(filtered)
+	for _, want := range []string{"grandparent", "parent", "child"} {
+		if !containsId(ids, want) {
+			t.Fatalf("expected %q kept as an ancestor of a match, got %v", want, ids)
+		}
+	}
+}
+
+func TestFilterImagesSinceExcludesOlder(t *testing.T) {
+	images := &[]Image{
+		{Id: "old", Created: 1},
+		{Id: "marker", Created: 2},
+		{Id: "new", Created: 3},
+	}
+
+	filtered, err := filterImages(images, []string{"since=marker"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ids := idsOf(filtered); len(ids) != 1 || ids[0] != "new" {
+		t.Fatalf("expected only images created after marker, got %v", ids)
+	}
+}
+
+func TestFilterImagesInvalidFilter(t *testing.T) {
+	images := &[]Image{{Id: "a"}}
+
+	if _, err := filterImages(images, []string{"notakeyvalue"}, ""); err == nil {
+		t.Fatal("expected an error for a filter without key=value")
+	}
+}
+
+func TestFilterImagesUnsupportedKey(t *testing.T) {
+	images := &[]Image{{Id: "a"}}
+
+	if _, err := filterImages(images, []string{"bogus=true"}, ""); err == nil {
+		t.Fatal("expected an error for an unsupported filter key")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// ImageSource knows how to list the images known to one container engine and
+// return them in dockviz's own Image shape, ready for filterImages and the
+// jsonToTree/renderGraph/jsonToShort renderers.
+type ImageSource interface {
+	ListImages() ([]Image, error)
+
+	// Engine reports which backend this source talks to ("docker" or
+	// "podman"), so callers that need a live connection of their own (the
+	// TUI's inspect/diff/rmi actions) know which one they'd be hitting.
+	Engine() string
+}
+
+// resolveImageSource picks the ImageSource for the given --engine value.
+// "auto" probes the known engines in order (docker, then podman) and uses
+// the first one whose socket is present (for docker, a set DOCKER_HOST also
+// counts); the socket is not dialed to check that the engine answers.
+func resolveImageSource(engine string) (ImageSource, error) {
+	switch engine {
+	case "", "auto":
+		if sock := dockerSocketPath(); sock != "" {
+			return &DockerImageSource{}, nil
+		}
+		if sock := podmanSocketPath(); sock != "" {
+			return &PodmanImageSource{socketPath: sock}, nil
+		}
+		return nil, fmt.Errorf("unable to autodetect a container engine, tried docker and podman sockets")
+	case "docker":
+		return &DockerImageSource{}, nil
+	case "podman":
+		sock := podmanSocketPath()
+		if sock == "" {
+			return nil, fmt.Errorf("unable to find a podman socket, tried /run/podman/podman.sock and $XDG_RUNTIME_DIR/podman/podman.sock")
+		}
+		return &PodmanImageSource{socketPath: sock}, nil
+	default:
+		return nil, fmt.Errorf("unsupported --engine %q, expected docker, podman, or auto", engine)
+	}
+}
+
+// DockerImageSource is the original behavior: talk to the Docker daemon via
+// go-dockerclient using the DOCKER_HOST/DOCKER_* environment conventions
+// already handled by connect().
+type DockerImageSource struct{}
+
+func (s *DockerImageSource) Engine() string { return "docker" }
+
+func (s *DockerImageSource) ListImages() ([]Image, error) {
+	client, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	clientImages, err := client.ListImages(docker.ListImagesOptions{All: true})
+	if err != nil {
+		if in_docker := os.Getenv("IN_DOCKER"); len(in_docker) > 0 {
+			return nil, fmt.Errorf("Unable to access Docker socket, please run like this:\n  docker run --rm -v /var/run/docker.sock:/var/run/docker.sock nate/dockviz images <args>\nFor more help, run 'dockviz help'")
+		}
+		return nil, fmt.Errorf("Unable to connect: %s\nFor help, run 'dockviz help'", err)
+	}
+
+	var ims []Image
+	for _, image := range clientImages {
+		ims = append(ims, Image{
+			Id:          image.ID,
+			ParentId:    image.ParentID,
+			RepoTags:    image.RepoTags,
+			Labels:      image.Labels,
+			VirtualSize: image.VirtualSize,
+			Size:        image.Size,
+			Created:     image.Created,
+		})
+	}
+
+	return ims, nil
+}
+
+func dockerSocketPath() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	if _, err := os.Stat("/var/run/docker.sock"); err == nil {
+		return "/var/run/docker.sock"
+	}
+	return ""
+}
+
+// podmanSocketPath looks for the podman REST socket in the two places the
+// podman docs point at: the system socket, or the per-user rootless socket
+// under $XDG_RUNTIME_DIR.
+func podmanSocketPath() string {
+	candidates := []string{"/run/podman/podman.sock"}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// PodmanImageSource talks to podman's libpod REST API over its unix socket.
+type PodmanImageSource struct {
+	socketPath string
+}
+
+func (s *PodmanImageSource) Engine() string { return "podman" }
+
+type podmanImage struct {
+	Id       string            `json:"Id"`
+	ParentId string            `json:"ParentId"`
+	RepoTags []string          `json:"RepoTags"`
+	Labels   map[string]string `json:"Labels"`
+	Size     int64             `json:"Size"`
+	Created  int64             `json:"Created"`
+}
+
+type podmanHistoryEntry struct {
+	Id      string `json:"id"`
+	Created int64  `json:"created"`
+	Comment string `json:"comment"`
+}
+
+func (s *PodmanImageSource) httpClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", s.socketPath)
+			},
+		},
+		Timeout: 30 * time.Second,
+	}
+}
+
+func (s *PodmanImageSource) ListImages() ([]Image, error) {
+	client := s.httpClient()
+
+	resp, err := client.Get("http://podman/v4.0.0/libpod/images/json")
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to podman at %s: %s", s.socketPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("podman returned %s listing images", resp.Status)
+	}
+
+	var podmanImages []podmanImage
+	if err := json.NewDecoder(resp.Body).Decode(&podmanImages); err != nil {
+		return nil, fmt.Errorf("error reading podman images response: %s", err)
+	}
+
+	var ims []Image
+	for _, image := range podmanImages {
+		parentId := image.ParentId
+		if parentId == "" {
+			// newer libimage releases stopped populating ParentId, so fall
+			// back to the layer history to recover it
+			if parent, err := s.parentFromHistory(client, image.Id); err == nil {
+				parentId = parent
+			}
+		}
+
+		ims = append(ims, Image{
+			Id:          image.Id,
+			ParentId:    parentId,
+			RepoTags:    image.RepoTags,
+			Labels:      image.Labels,
+			VirtualSize: image.Size,
+			Size:        image.Size,
+			Created:     image.Created,
+		})
+	}
+
+	return ims, nil
+}
+
+// parentFromHistory reconstructs an image's parent by walking the layer
+// chain returned by /libpod/images/{id}/history: the entry immediately
+// before this image's own top layer is its parent.
+func (s *PodmanImageSource) parentFromHistory(client *http.Client, id string) (string, error) {
+	resp, err := client.Get(fmt.Sprintf("http://podman/v4.0.0/libpod/images/%s/history", id))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("podman returned %s fetching history for %s", resp.Status, id)
+	}
+
+	var history []podmanHistoryEntry
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		return "", err
+	}
+
+	for i, entry := range history {
+		if entry.Id == id && i+1 < len(history) {
+			return history[i+1].Id, nil
+		}
+	}
+
+	return "", fmt.Errorf("no parent found for %s in history", id)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Node is a single line in the tree/dot output. Most nodes are images, but
+// --layers and --platforms make dockviz synthesize extra leaf nodes (one per
+// history layer or manifest-list platform) so cache reuse and multi-arch
+// fan-out show up in the same rendering.
+type Node interface {
+	// NodeId uniquely identifies the node so the dot, mermaid, and
+	// json-graph renderers can key edges and styles off of it.
+	NodeId() string
+	// Kind is "image", "layer", "platform", or "container"; the json-graph
+	// export carries it as each node's "kind" (writeDotNode picks a node's
+	// glyph/color by its concrete type).
+	Kind() string
+	// Label renders the node's single line of text (no prefix, no newline).
+	Label(noTrunc bool) string
+	Children() []Node
+}
+
+// ImageNode wraps an Image and whatever layer/platform nodes were
+// synthesized underneath it.
+type ImageNode struct {
+	Image    Image
+	children []Node
+}
+
+func (n *ImageNode) NodeId() string   { return n.Image.Id }
+func (n *ImageNode) Kind() string     { return "image" }
+func (n *ImageNode) Children() []Node { return n.children }
+func (n *ImageNode) AddChild(c Node)  { n.children = append(n.children, c) }
+
+func (n *ImageNode) Label(noTrunc bool) string {
+	var imageID string
+	if noTrunc {
+		imageID = n.Image.Id
+	} else {
+		imageID = truncate(n.Image.Id)
+	}
+
+	label := fmt.Sprintf("%s Virtual Size: %s", imageID, humanSize(n.Image.VirtualSize))
+	if len(n.Image.RepoTags) > 0 && n.Image.RepoTags[0] != "<none>:<none>" {
+		label += fmt.Sprintf(" Tags: %s", strings.Join(n.Image.RepoTags, ", "))
+	}
+	return label
+}
+
+// PlatformNode represents one entry of a manifest list, e.g. linux/arm64/v8.
+type PlatformNode struct {
+	Platform string
+	Digest   string
+}
+
+func (n *PlatformNode) NodeId() string   { return n.Platform + "@" + n.Digest }
+func (n *PlatformNode) Kind() string     { return "platform" }
+func (n *PlatformNode) Children() []Node { return nil }
+
+func (n *PlatformNode) Label(noTrunc bool) string {
+	digest := n.Digest
+	if !noTrunc && len(digest) > 19 { // "sha256:" + 12 hex chars
+		digest = truncateDigest(digest)
+	}
+	return fmt.Sprintf("%s digest:%s", n.Platform, digest)
+}
+
+// LayerNode represents one entry from an image's build history.
+type LayerNode struct {
+	CreatedBy string
+	Size      int64
+}
+
+func (n *LayerNode) NodeId() string   { return fmt.Sprintf("%p", n) }
+func (n *LayerNode) Kind() string     { return "layer" }
+func (n *LayerNode) Children() []Node { return nil }
+
+func (n *LayerNode) Label(noTrunc bool) string {
+	createdBy := n.CreatedBy
+	if !noTrunc && len(createdBy) > 60 {
+		createdBy = createdBy[0:57] + "..."
+	}
+	return fmt.Sprintf("%s (%s)", createdBy, humanSize(n.Size))
+}
+
+// ContainerNode represents a container pinned to an image - a leaf added
+// under its image's node by --with-containers so it's obvious which images
+// can't be `rmi`'d without stopping/removing something first.
+type ContainerNode struct {
+	Container ContainerRef
+}
+
+func (n *ContainerNode) NodeId() string   { return n.Container.Id }
+func (n *ContainerNode) Kind() string     { return "container" }
+func (n *ContainerNode) Children() []Node { return nil }
+
+func (n *ContainerNode) Label(noTrunc bool) string {
+	id := n.Container.Id
+	if !noTrunc {
+		id = truncate(id)
+	}
+	return fmt.Sprintf("◆ %s %s (%s)", id, n.Container.Name, n.Container.Status)
+}
+
+func truncateDigest(digest string) string {
+	if idx := strings.Index(digest, ":"); idx >= 0 {
+		hash := digest[idx+1:]
+		if len(hash) > 12 {
+			hash = hash[0:12]
+		}
+		return digest[0:idx+1] + hash
+	}
+	return digest
+}
+
+// buildImageNodes turns a flat image list into a forest of ImageNode trees,
+// attaching layer/platform children when requested. If startImageArg is set,
+// only that image's ImageNode is returned (as the sole root).
+func buildImageNodes(images []Image, startImageArg string, withLayers bool, withPlatforms bool, withContainers bool) []Node {
+	nodesById := make(map[string]*ImageNode)
+	for _, image := range images {
+		nodesById[image.Id] = &ImageNode{Image: image}
+	}
+
+	var roots []Node
+	for _, image := range images {
+		node := nodesById[image.Id]
+
+		if withPlatforms {
+			for _, platform := range image.Platforms {
+				node.AddChild(&PlatformNode{Platform: platform, Digest: image.Digest})
+			}
+		}
+		if withLayers {
+			for _, layer := range image.Layers {
+				node.AddChild(&LayerNode{CreatedBy: layer.CreatedBy, Size: layer.Size})
+			}
+		}
+		if withContainers {
+			for _, container := range image.Containers {
+				node.AddChild(&ContainerNode{Container: container})
+			}
+		}
+
+		if image.ParentId == "" {
+			roots = append(roots, node)
+		} else if parent, exists := nodesById[image.ParentId]; exists {
+			parent.AddChild(node)
+		} else {
+			// parent isn't in the filtered set, so this node is a root too
+			roots = append(roots, node)
+		}
+	}
+
+	// Every parent/child edge is now wired regardless of startImageArg, so
+	// narrowing down to a single start node here keeps its descendants.
+	if startImageArg != "" {
+		if node, exists := nodesById[startImageArg]; exists {
+			return []Node{node}
+		}
+		return nil
+	}
+
+	return roots
+}
+
+func WalkTree(buffer *bytes.Buffer, noTrunc bool, nodes []Node, prefix string) {
+	length := len(nodes)
+	for index, node := range nodes {
+		var branch, childPrefix string
+		if index+1 == length {
+			branch, childPrefix = "└─", prefix+"  "
+		} else {
+			branch, childPrefix = "├─", prefix+"│ "
+		}
+
+		PrintTreeNode(buffer, noTrunc, node, prefix+branch)
+		if children := node.Children(); len(children) > 0 {
+			WalkTree(buffer, noTrunc, children, childPrefix)
+		}
+	}
+}
+
+func PrintTreeNode(buffer *bytes.Buffer, noTrunc bool, node Node, prefix string) {
+	buffer.WriteString(prefix)
+	buffer.WriteString(node.Label(noTrunc))
+	buffer.WriteString("\n")
+}
@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+func TestBuildImageNodesParentChildWiring(t *testing.T) {
+	images := []Image{
+		{Id: "parent"},
+		{Id: "child", ParentId: "parent"},
+	}
+
+	roots := buildImageNodes(images, "", false, false, false)
+	if len(roots) != 1 {
+		t.Fatalf("expected a single root, got %d", len(roots))
+	}
+
+	root := roots[0]
+	if root.NodeId() != "parent" {
+		t.Fatalf("expected root to be %q, got %q", "parent", root.NodeId())
+	}
+	if children := root.Children(); len(children) != 1 || children[0].NodeId() != "child" {
+		t.Fatalf("expected parent's child to be %q, got %v", "child", children)
+	}
+}
+
+func TestBuildImageNodesMissingParentBecomesRoot(t *testing.T) {
+	images := []Image{
+		{Id: "orphan", ParentId: "does-not-exist"},
+	}
+
+	roots := buildImageNodes(images, "", false, false, false)
+	if len(roots) != 1 || roots[0].NodeId() != "orphan" {
+		t.Fatalf("expected the orphan to surface as its own root, got %v", roots)
+	}
+}
+
+func TestBuildImageNodesStartImageKeepsDescendants(t *testing.T) {
+	images := []Image{
+		{Id: "grandparent"},
+		{Id: "parent", ParentId: "grandparent"},
+		{Id: "child", ParentId: "parent"},
+	}
+
+	roots := buildImageNodes(images, "parent", false, false, false)
+	if len(roots) != 1 || roots[0].NodeId() != "parent" {
+		t.Fatalf("expected the start image as the sole root, got %v", roots)
+	}
+
+	children := roots[0].Children()
+	if len(children) != 1 || children[0].NodeId() != "child" {
+		t.Fatalf("expected the start image's descendants to still be wired, got %v", children)
+	}
+}
+
+func TestBuildImageNodesUnknownStartImage(t *testing.T) {
+	images := []Image{{Id: "a"}}
+
+	if roots := buildImageNodes(images, "does-not-exist", false, false, false); roots != nil {
+		t.Fatalf("expected no roots for an unknown start image, got %v", roots)
+	}
+}
+
+func TestBuildImageNodesLayersAndPlatforms(t *testing.T) {
+	images := []Image{
+		{
+			Id:        "a",
+			Digest:    "sha256:abc",
+			Platforms: []string{"linux/amd64"},
+			Layers:    []ImageLayer{{CreatedBy: "RUN x", Size: 10}},
+		},
+	}
+
+	roots := buildImageNodes(images, "", true, true, false)
+	if len(roots) != 1 {
+		t.Fatalf("expected a single root, got %d", len(roots))
+	}
+
+	children := roots[0].Children()
+	if len(children) != 2 {
+		t.Fatalf("expected a platform node and a layer node, got %v", children)
+	}
+
+	var sawPlatform, sawLayer bool
+	for _, child := range children {
+		switch child.Kind() {
+		case "platform":
+			sawPlatform = true
+		case "layer":
+			sawLayer = true
+		}
+	}
+	if !sawPlatform || !sawLayer {
+		t.Fatalf("expected both a platform and a layer child, got %v", children)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ServeCommand runs a small web server that polls the configured engine on
+// an interval and serves an auto-refreshing page with the image graph, so
+// it can be left open on a dashboard instead of re-run by hand.
+type ServeCommand struct {
+	Listen   string `long:"listen" description:"Address to listen on." default:":8080"`
+	Interval int    `long:"interval" description:"Seconds between polls of the engine." default:"10"`
+	Engine   string `long:"engine" description:"Image source to query: docker, podman, or auto." default:"auto"`
+}
+
+var serveCommand ServeCommand
+
+func (x *ServeCommand) Execute(args []string) error {
+	http.HandleFunc("/", x.handleIndex)
+	http.HandleFunc("/graph.svg", x.handleGraph)
+
+	log.Printf("dockviz serve listening on %s, polling every %ds", x.Listen, x.Interval)
+	return http.ListenAndServe(x.Listen, nil)
+}
+
+func (x *ServeCommand) currentImages() (*[]Image, error) {
+	source, err := resolveImageSource(x.Engine)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := source.ListImages()
+	if err != nil {
+		return nil, err
+	}
+
+	return &images, nil
+}
+
+var serveIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>dockviz</title>
+  <meta http-equiv="refresh" content="{{.Interval}}">
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    form { margin-bottom: 1em; }
+  </style>
+</head>
+<body>
+  <h1>dockviz</h1>
+  <form method="get">
+    <input type="text" name="filter" placeholder="label=foo, dangling=true, ..." value="{{.Filter}}">
+    <button type="submit">filter</button>
+  </form>
+  <img src="/graph.svg?filter={{.Filter}}" alt="image graph">
+</body>
+</html>
+`))
+
+func (x *ServeCommand) handleIndex(w http.ResponseWriter, r *http.Request) {
+	serveIndexTemplate.Execute(w, struct {
+		Interval int
+		Filter   string
+	}{
+		Interval: x.Interval,
+		Filter:   r.URL.Query().Get("filter"),
+	})
+}
+
+func (x *ServeCommand) handleGraph(w http.ResponseWriter, r *http.Request) {
+	images, err := x.currentImages()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	var filters []string
+	if filter := r.URL.Query().Get("filter"); filter != "" {
+		for _, predicate := range strings.Split(filter, ",") {
+			if predicate = strings.TrimSpace(predicate); predicate != "" {
+				filters = append(filters, predicate)
+			}
+		}
+	}
+
+	filtered, err := filterImages(images, filters, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// renderGraph("svg", ...) shells out to Graphviz's `dot` binary (see
+	// renderWithGraphviz in export.go) - dockviz doesn't bundle its own DOT
+	// renderer, so `dot` must be on PATH wherever `dockviz serve` runs.
+	svg, err := renderGraph(filtered, "svg", false, false, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", x.Interval))
+	fmt.Fprint(w, svg)
+}
+
+func init() {
+	parser.AddCommand("serve",
+		"Serve an auto-refreshing image graph over HTTP.",
+		"",
+		&serveCommand)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsouza/go-dockerclient"
+)
+
+// tuiRow is one flattened, currently-visible line of the tree: a Node plus
+// how deep it is, so View() can indent it without re-walking the tree.
+type tuiRow struct {
+	node  Node
+	depth int
+}
+
+// tuiModel is the bubbletea model behind `dockviz images --tui`. It renders
+// the exact same Node tree jsonToTree builds, just interactively: the tree
+// itself is the source of truth, View() and the filter/inspect panes are
+// just different ways of looking at it.
+type tuiModel struct {
+	client   *docker.Client
+	engine   string
+	roots    []Node
+	rows     []tuiRow
+	cursor   int
+	expanded map[string]bool
+	noTrunc  bool
+
+	filtering bool
+	filter    string
+
+	inspecting  bool
+	inspectText string
+
+	diffing  bool
+	diffText string
+
+	confirmRemove bool
+	status        string
+}
+
+func newTUIModel(client *docker.Client, engine string, images []Image, noTrunc bool, withLayers bool, withPlatforms bool, withContainers bool) *tuiModel {
+	roots := buildImageNodes(images, "", withLayers, withPlatforms, withContainers)
+
+	m := &tuiModel{
+		client:   client,
+		engine:   engine,
+		roots:    roots,
+		expanded: make(map[string]bool),
+		noTrunc:  noTrunc,
+	}
+
+	// start fully expanded, matching the static tree output
+	var expandAll func(nodes []Node)
+	expandAll = func(nodes []Node) {
+		for _, n := range nodes {
+			m.expanded[n.NodeId()] = true
+			expandAll(n.Children())
+		}
+	}
+	expandAll(roots)
+
+	m.rebuildRows()
+	return m
+}
+
+func (m *tuiModel) rebuildRows() {
+	m.rows = nil
+
+	var visit func(nodes []Node, depth int)
+	visit = func(nodes []Node, depth int) {
+		for _, n := range nodes {
+			if m.filter != "" && !nodeMatchesFilter(n, m.filter) {
+				continue
+			}
+			m.rows = append(m.rows, tuiRow{node: n, depth: depth})
+			if m.expanded[n.NodeId()] {
+				visit(n.Children(), depth+1)
+			}
+		}
+	}
+	visit(m.roots, 0)
+
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// nodeMatchesFilter reports whether n, or any of its descendants, matches
+// the fuzzy repo:tag/id filter - a node stays visible as long as something
+// under it does, so the tree doesn't fragment when filtering.
+func nodeMatchesFilter(n Node, filter string) bool {
+	if imageNode, ok := n.(*ImageNode); ok {
+		if strings.Contains(imageNode.Image.Id, filter) {
+			return true
+		}
+		for _, repotag := range imageNode.Image.RepoTags {
+			if strings.Contains(repotag, filter) {
+				return true
+			}
+		}
+	}
+
+	for _, child := range n.Children() {
+		if nodeMatchesFilter(child, filter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.filtering {
+		return m.updateFiltering(keyMsg)
+	}
+	if m.confirmRemove {
+		return m.updateConfirmRemove(keyMsg)
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "enter":
+		if node := m.currentNode(); node != nil && len(node.Children()) > 0 {
+			m.expanded[node.NodeId()] = !m.expanded[node.NodeId()]
+			m.rebuildRows()
+		}
+	case "/":
+		m.filtering = true
+		m.status = ""
+	case "i":
+		if m.requireDockerEngine() {
+			m.toggleInspect()
+		}
+	case "d":
+		if m.requireDockerEngine() {
+			m.toggleDiff()
+		}
+	case "x":
+		if !m.requireDockerEngine() {
+			break
+		}
+		if imageNode, ok := m.currentNode().(*ImageNode); ok {
+			m.confirmRemove = true
+			m.status = fmt.Sprintf("Remove %s? (y/n)", truncate(imageNode.Image.Id))
+		} else {
+			m.status = "only images can be removed"
+		}
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) updateFiltering(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch keyMsg.String() {
+	case "enter", "esc":
+		m.filtering = false
+		m.rebuildRows()
+	case "backspace":
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+		}
+		m.rebuildRows()
+	default:
+		m.filter += keyMsg.String()
+		m.rebuildRows()
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) updateConfirmRemove(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch keyMsg.String() {
+	case "y":
+		m.confirmRemove = false
+		if imageNode, ok := m.currentNode().(*ImageNode); ok {
+			if out, err := exec.Command("docker", "rmi", imageNode.Image.Id).CombinedOutput(); err != nil {
+				m.status = fmt.Sprintf("rmi failed: %s", strings.TrimSpace(string(out)))
+			} else {
+				m.status = fmt.Sprintf("removed %s", truncate(imageNode.Image.Id))
+			}
+		}
+	case "n", "esc":
+		m.confirmRemove = false
+		m.status = ""
+	}
+
+	return m, nil
+}
+
+// requireDockerEngine guards the actions (inspect/diff/rmi) that only know
+// how to talk to the Docker daemon: selecting a podman image and pressing
+// one of these keys would otherwise hit docker with a podman image ID.
+func (m *tuiModel) requireDockerEngine() bool {
+	if m.engine != "docker" {
+		m.status = fmt.Sprintf("inspect/diff/rmi need --engine=docker, not %s", m.engine)
+		return false
+	}
+	return true
+}
+
+func (m *tuiModel) currentNode() Node {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor].node
+}
+
+func (m *tuiModel) toggleInspect() {
+	if m.inspecting {
+		m.inspecting = false
+		return
+	}
+
+	node := m.currentNode()
+	imageNode, ok := node.(*ImageNode)
+	if !ok {
+		return
+	}
+
+	detail, err := m.client.InspectImage(imageNode.Image.Id)
+	if err != nil {
+		m.status = fmt.Sprintf("inspect failed: %s", err)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Labels:      %v\n", detail.Config.Labels)
+	fmt.Fprintf(&b, "Env:         %v\n", detail.Config.Env)
+	fmt.Fprintf(&b, "Cmd:         %v\n", detail.Config.Cmd)
+	fmt.Fprintf(&b, "Entrypoint:  %v\n", detail.Config.Entrypoint)
+
+	m.inspectText = b.String()
+	m.inspecting = true
+}
+
+func (m *tuiModel) toggleDiff() {
+	if m.diffing {
+		m.diffing = false
+		return
+	}
+
+	node := m.currentNode()
+	imageNode, ok := node.(*ImageNode)
+	if !ok {
+		return
+	}
+
+	layers, err := fetchLayers(m.client, imageNode.Image.Id)
+	if err != nil {
+		m.status = fmt.Sprintf("history failed: %s", err)
+		return
+	}
+
+	var parentLayers []ImageLayer
+	if imageNode.Image.ParentId != "" {
+		parentLayers, _ = fetchLayers(m.client, imageNode.Image.ParentId)
+	}
+
+	var b strings.Builder
+	b.WriteString("Layers added relative to parent:\n")
+	for i := 0; i < len(layers)-len(parentLayers); i++ {
+		fmt.Fprintf(&b, "  %s (%s)\n", layers[i].CreatedBy, humanSize(layers[i].Size))
+	}
+
+	m.diffText = b.String()
+	m.diffing = true
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	for i, row := range m.rows {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		b.WriteString(cursor)
+		b.WriteString(strings.Repeat("  ", row.depth))
+		b.WriteString(row.node.Label(m.noTrunc))
+		b.WriteString("\n")
+	}
+
+	if m.filtering {
+		fmt.Fprintf(&b, "\n/%s", m.filter)
+	}
+	if m.inspecting {
+		b.WriteString("\n--- inspect ---\n")
+		b.WriteString(m.inspectText)
+	}
+	if m.diffing {
+		b.WriteString("\n--- diff vs parent ---\n")
+		b.WriteString(m.diffText)
+	}
+	if m.status != "" {
+		fmt.Fprintf(&b, "\n%s\n", m.status)
+	}
+
+	b.WriteString("\n(arrows move, enter expand/collapse, / filter, i inspect, d diff, x rmi, q quit)\n")
+
+	return b.String()
+}
+
+// runImagesTUI launches the full-screen tree browser. The tree itself works
+// against any engine, but inspect/diff/rmi (i/d/x) need a live Docker
+// connection, so those are only enabled when engine is "docker" -
+// requireDockerEngine turns them into a no-op with a status message
+// otherwise. We only dial Docker at all when engine == "docker", so
+// browsing a podman tree doesn't require a docker daemon. Piped-in input
+// is treated as docker (see ImagesCommand.Execute), so it does connect.
+func runImagesTUI(images []Image, engine string, noTrunc bool, withLayers bool, withPlatforms bool, withContainers bool) error {
+	var client *docker.Client
+	if engine == "docker" {
+		var err error
+		client, err = connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	program := tea.NewProgram(newTUIModel(client, engine, images, noTrunc, withLayers, withPlatforms, withContainers))
+	_, err := program.Run()
+	return err
+}